@@ -6,7 +6,7 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"reflect"
+	"path/filepath"
 	"strconv"
 	"time"
 
@@ -20,10 +20,22 @@ import (
 type Client struct {
 	KeepAlive float64
 
-	activeConn  *ConnStore
-	maxLifeConn time.Duration
+	// PoolSize is how many connections GetResource keeps open per target,
+	// acquired via the ConnStore's Selector. Defaults to 1 (the historical
+	// one-conn-per-target behavior) when unset.
+	PoolSize int
 
-	// TODO : utilize below args
+	activeConn    *ConnStore
+	maxLifeConn   time.Duration
+	protoRegistry *ProtoRegistry
+
+	unaryInterceptors  []grpc.UnaryClientInterceptor
+	streamInterceptors []grpc.StreamClientInterceptor
+	collector          *Collector
+
+	perRPCCreds credentials.PerRPCCredentials
+
+	// set via the With* Options passed to NewClient
 	headers        []string
 	reflectHeaders []string
 	authority      string
@@ -43,9 +55,12 @@ type Proto struct {
 	Content []byte
 }
 
-// NewClient constructor
-func NewClient() *Client {
-	maxLife, tick := 10, 3
+// NewClient constructor. Env vars MAX_LIFE_CONN, TICK_CLOSE_CONN and
+// POOL_SIZE seed the defaults below. Of these, only MAX_LIFE_CONN has an
+// Option (WithMaxLifeConn) to override it; other Options set up
+// TLS/auth/unix-socket dialing, which have no env-var equivalent.
+func NewClient(opts ...Option) *Client {
+	maxLife, tick, poolSize := 10, 3, 1
 
 	if val, err := strconv.Atoi(os.Getenv("MAX_LIFE_CONN")); err == nil {
 		maxLife = val
@@ -55,13 +70,23 @@ func NewClient() *Client {
 		tick = val
 	}
 
+	if val, err := strconv.Atoi(os.Getenv("POOL_SIZE")); err == nil && val > 0 {
+		poolSize = val
+	}
+
 	c := NewConnectionStore()
 	g := &Client{
-		activeConn: c,
+		activeConn:    c,
+		PoolSize:      poolSize,
+		maxLifeConn:   time.Duration(maxLife) * time.Minute,
+		protoRegistry: NewProtoRegistry(filepath.Join(protoDir, ".cache")),
+	}
+
+	for _, opt := range opts {
+		opt(g)
 	}
 
-	if maxLife > 0 && tick > 0 {
-		g.maxLifeConn = time.Duration(maxLife) * time.Minute
+	if g.maxLifeConn > 0 && tick > 0 {
 		c.StartGC(time.Duration(tick) * time.Second)
 	}
 
@@ -80,6 +105,16 @@ func (g *Client) ClearHeaders(headers []string) {
 	g.headers = []string{}
 }
 
+// Use appends unary and stream interceptors to the client's chain. They run
+// in the order added, via grpc.WithChainUnaryInterceptor /
+// grpc.WithChainStreamInterceptor, and apply to every connection dialed
+// afterwards. Go doesn't allow two variadic parameters on one func, so
+// pass nil for whichever chain you're not extending.
+func (g *Client) Use(unary []grpc.UnaryClientInterceptor, stream []grpc.StreamClientInterceptor) {
+	g.unaryInterceptors = append(g.unaryInterceptors, unary...)
+	g.streamInterceptors = append(g.streamInterceptors, stream...)
+}
+
 func (g *Client) Invoke(ctx context.Context, target, service, method string, data string) (
 	reply string, cost time.Duration, err error) {
 
@@ -92,56 +127,136 @@ func (g *Client) Invoke(ctx context.Context, target, service, method string, dat
 	if err != nil {
 		return
 	}
+	defer g.activeConn.release(rs)
+
 	buf := bytes.NewBuffer([]byte(data))
 	//return rs.Invoke(ctx, fmt.Sprintf("proto.%s.%s", service, method), buf)
 	return rs.Invoke(ctx, fmt.Sprintf("%s.%s", service, method), buf)
 }
 
-// GetResource - open resource to targeted grpc server
+// GetResource - acquire a resource for target out of its connection pool,
+// dialing a new one if the pool isn't yet at PoolSize. target may be a
+// plain host:port or a logical "<scheme>://..." address with a Resolver
+// registered for that scheme (see RegisterResolver), in which case the
+// pool tracks one connection per resolved address instead. The returned
+// Resource counts as in-flight until the caller passes it to
+// g.activeConn.release.
 func (g *Client) GetResource(ctx context.Context, target string, plainText, isRestartConn bool) (*Resource, error) {
-	if r, ok := g.activeConn.getConnection(target); ok {
-		if !isRestartConn && r.isValid() {
-			h := append(g.headers, g.reflectHeaders...)
-			r.headers = h
-			return r, nil
-		}
+	if isRestartConn {
 		g.CloseActiveConns(target)
 	}
 
-	var err error
-	r := new(Resource)
 	h := append(g.headers, g.reflectHeaders...)
-	r.md = grpcurl.MetadataFromHeaders(h)
-	r.clientConn, err = g.dial(ctx, target, plainText)
+	makeDial := func(dialCtx context.Context) func(addr string) (*Resource, error) {
+		return func(addr string) (*Resource, error) {
+			nr := new(Resource)
+			nr.md = grpcurl.MetadataFromHeaders(h)
+			cc, err := g.dial(dialCtx, addr, plainText)
+			if err != nil {
+				return nil, err
+			}
+			nr.clientConn = cc
+			return nr, nil
+		}
+	}
+
+	var r *Resource
+	var err error
+	if scheme, _ := splitScheme(target); scheme != "" {
+		resolver, ok := resolverFor(scheme)
+		if !ok {
+			return nil, fmt.Errorf("no resolver registered for scheme %q", scheme)
+		}
+		// ensureResolved's background watch goroutine keeps calling this dial
+		// for as long as the target is followed, long after this call's ctx
+		// is gone, so it must not derive from ctx.
+		if err = g.activeConn.ensureResolved(target, resolver, makeDial(context.Background()), g.maxLifeConn); err != nil {
+			return nil, err
+		}
+		r, err = g.activeConn.acquireResolved(target)
+	} else {
+		r, err = g.activeConn.acquire(target, g.PoolSize, g.maxLifeConn, func() (*Resource, error) {
+			return makeDial(ctx)(target)
+		})
+	}
 	if err != nil {
 		return nil, err
 	}
 
 	r.headers = h
-
-	g.activeConn.addConnection(target, r, g.maxLifeConn)
 	return r, nil
 }
 
-// GetResourceWithProto - open resource to targeted grpc server using given protofile
+// GetResourceWithProto - open resource to targeted grpc server using given
+// protofile, compiling it through the client's ProtoRegistry so resources
+// sharing the same schema reuse the parsed descriptors.
 func (g *Client) GetResourceWithProto(ctx context.Context, target string, plainText, isRestartConn bool, protos []Proto) (*Resource, error) {
 	r, err := g.GetResource(ctx, target, plainText, isRestartConn)
 	if err != nil {
 		return nil, err
 	}
 
-	// if given protofile is equal to current, skip adding protos as it's already
-	// persisted in the harddisk anyway
-	if reflect.DeepEqual(r.protos, protos) {
-		return r, nil
+	digest, err := g.protoRegistry.Register("", protos)
+	if err != nil {
+		g.activeConn.release(r)
+		return nil, err
+	}
+
+	fds, ok := g.protoRegistry.Get(digest)
+	if !ok {
+		g.activeConn.release(r)
+		return nil, fmt.Errorf("proto digest %q missing after successful register", digest)
+	}
+	r.attachDigest(digest, fds)
+	r.protos = protos
+	return r, nil
+}
+
+// RegisterProtos compiles protos through the client's ProtoRegistry and
+// records name as an alias for the resulting digest, so callers can upload
+// a schema once and attach it to later connections by reference via
+// GetResourceWithProtoDigest instead of re-uploading and re-parsing it.
+func (g *Client) RegisterProtos(name string, protos []Proto) (digest string, err error) {
+	return g.protoRegistry.Register(name, protos)
+}
+
+// GetResourceWithProtoDigest opens target and attaches the previously
+// registered schema identified by digest (as returned by RegisterProtos).
+func (g *Client) GetResourceWithProtoDigest(ctx context.Context, target string, plainText bool, digest string) (*Resource, error) {
+	fds, ok := g.protoRegistry.Get(digest)
+	if !ok {
+		return nil, fmt.Errorf("unknown proto digest %q", digest)
+	}
+
+	r, err := g.GetResource(ctx, target, plainText, false)
+	if err != nil {
+		return nil, err
+	}
+
+	r.attachDigest(digest, fds)
+	return r, nil
+}
+
+// GetResourceWithProtoName opens target and attaches the schema last
+// registered under name via RegisterProtos, resolving it to a digest
+// through the client's ProtoRegistry.
+func (g *Client) GetResourceWithProtoName(ctx context.Context, target string, plainText bool, name string) (*Resource, error) {
+	digest, ok := g.protoRegistry.Resolve(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown proto name %q", name)
 	}
+	return g.GetResourceWithProtoDigest(ctx, target, plainText, digest)
+}
 
-	// add protos property to resource and persist it to harddisk
-	err = r.AddProtos(protos)
-	return r, err
+// ReleaseResource returns a resource acquired via GetResource (or
+// GetResourceWithProto) back to its pool, decrementing its in-flight count
+// so the Selector can take load into account for the next acquire.
+func (g *Client) ReleaseResource(r *Resource) {
+	g.activeConn.release(r)
 }
 
-// GetActiveConns - get all saved active connection
+// GetActiveConns - get all targets that have a connection pool; a pool may
+// hold no connections, e.g. after they expired or a dial failed
 func (g *Client) GetActiveConns(ctx context.Context) []string {
 	active := g.activeConn.getAllConn()
 	result := make([]string, len(active))
@@ -190,6 +305,17 @@ func (g *Client) dial(ctx context.Context, target string, plainText bool) (*grpc
 		opts = append(opts, grpc.WithAuthority(g.authority))
 	}
 
+	if len(g.unaryInterceptors) > 0 {
+		opts = append(opts, grpc.WithChainUnaryInterceptor(g.unaryInterceptors...))
+	}
+	if len(g.streamInterceptors) > 0 {
+		opts = append(opts, grpc.WithChainStreamInterceptor(g.streamInterceptors...))
+	}
+
+	if g.perRPCCreds != nil {
+		opts = append(opts, grpc.WithPerRPCCredentials(g.perRPCCreds))
+	}
+
 	var creds credentials.TransportCredentials
 	if !plainText {
 		var err error
@@ -0,0 +1,388 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// connPool holds every open connection to a single target.
+type connPool struct {
+	conns []*Resource
+
+	// draining holds connections for addresses reconcile no longer wants,
+	// kept open only until their in-flight RPCs finish so a resolver update
+	// can't tear down a call out from under a caller; reapIdle closes them
+	// once idle.
+	draining []*Resource
+}
+
+// ConnStore is a pool of connections keyed by target: each target may have
+// up to PoolSize live connections, acquired round-robin (or by whatever
+// Selector is configured) to spread concurrent RPCs across them instead of
+// serializing on a single *grpc.ClientConn.
+type ConnStore struct {
+	mu    sync.Mutex
+	pools map[string]*connPool
+
+	// watches tracks resolver-backed logical targets that already have a
+	// reconciliation goroutine running, so ensureResolved only starts one
+	// per target.
+	watches map[string]context.CancelFunc
+
+	// resolving tracks a target's first, in-flight resolve+reconcile, so
+	// concurrent ensureResolved callers for a brand-new target wait on the
+	// same attempt (and see the same error) instead of racing to both
+	// resolve it, or returning before its pool is actually populated.
+	resolving map[string]*resolveJob
+
+	selector Selector
+
+	// minIdle is the warm floor: the GC loop never reaps a pool below this
+	// many connections, however long they've sat idle.
+	minIdle int
+	// idleTimeout is how long a connection beyond the warm floor may sit
+	// with zero in-flight RPCs before the GC loop closes it.
+	idleTimeout time.Duration
+
+	stopGC chan struct{}
+}
+
+// NewConnectionStore builds an empty ConnStore using a round-robin
+// Selector by default.
+func NewConnectionStore() *ConnStore {
+	return &ConnStore{
+		pools:       make(map[string]*connPool),
+		watches:     make(map[string]context.CancelFunc),
+		resolving:   make(map[string]*resolveJob),
+		selector:    NewRoundRobinSelector(),
+		minIdle:     1,
+		idleTimeout: time.Minute,
+	}
+}
+
+// resolveJob tracks a single in-progress ensureResolved call so concurrent
+// callers for the same brand-new target can wait on it and get back the
+// same error, only once its pool has actually been populated.
+type resolveJob struct {
+	wg  sync.WaitGroup
+	err error
+}
+
+// ensureResolved starts following target's resolved address set via
+// resolver, dialing a connection per address with dial and keeping the
+// pool in sync as resolver pushes updates. It's a no-op on every call
+// after the first successful one for a given target; a failed resolve
+// leaves the target unwatched, so the next call tries again.
+func (c *ConnStore) ensureResolved(target string, resolver Resolver, dial func(addr string) (*Resource, error), maxLife time.Duration) error {
+	c.mu.Lock()
+	if _, ok := c.watches[target]; ok {
+		c.mu.Unlock()
+		return nil
+	}
+	if job, ok := c.resolving[target]; ok {
+		c.mu.Unlock()
+		job.wg.Wait()
+		return job.err
+	}
+	job := &resolveJob{}
+	job.wg.Add(1)
+	c.resolving[target] = job
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.resolving, target)
+		c.mu.Unlock()
+		job.wg.Done()
+	}()
+
+	watchCtx, cancel := context.WithCancel(context.Background())
+	addrs, updates, err := resolver.Resolve(watchCtx, target)
+	if err != nil {
+		cancel()
+		job.err = fmt.Errorf("resolve %q: %w", target, err)
+		return job.err
+	}
+
+	// Populate the pool before recording target as watched: watches is what
+	// lets a concurrent caller take the fast "already watching" path below,
+	// so it must not look watched until the pool actually has connections.
+	c.reconcile(target, addrs, dial, maxLife)
+
+	c.mu.Lock()
+	c.watches[target] = cancel
+	c.mu.Unlock()
+
+	go func() {
+		for addrs := range updates {
+			c.reconcile(target, addrs, dial, maxLife)
+		}
+	}()
+
+	return nil
+}
+
+// reconcile makes target's pool match addrs: it dials connections for
+// newly-seen addresses and retires ones that have dropped out. An address
+// whose dial fails is skipped until the next update lists it again. A
+// retired connection with RPCs still in flight is moved to the pool's
+// draining list rather than closed outright, so reapIdle can close it once
+// those calls finish instead of breaking them mid-flight.
+func (c *ConnStore) reconcile(target string, addrs []string, dial func(addr string) (*Resource, error), maxLife time.Duration) {
+	want := make(map[string]bool, len(addrs))
+	for _, a := range addrs {
+		want[a] = true
+	}
+
+	c.mu.Lock()
+	pool, ok := c.pools[target]
+	if !ok {
+		pool = &connPool{}
+		c.pools[target] = pool
+	}
+	have := make(map[string]bool, len(pool.conns))
+	kept := pool.conns[:0]
+	for _, r := range pool.conns {
+		if want[r.addr] {
+			have[r.addr] = true
+			kept = append(kept, r)
+		} else if r.outstanding() == 0 {
+			_ = r.Close()
+		} else {
+			pool.draining = append(pool.draining, r)
+		}
+	}
+	pool.conns = kept
+	c.mu.Unlock()
+
+	for _, a := range addrs {
+		if have[a] {
+			continue
+		}
+		r, err := dial(a)
+		if err != nil {
+			continue
+		}
+		r.addr = a
+		r.setExpiry(maxLife)
+
+		c.mu.Lock()
+		pool.conns = append(pool.conns, r)
+		c.mu.Unlock()
+	}
+}
+
+// acquireResolved hands out a connection from a resolver-backed target's
+// pool, which must already have been populated by ensureResolved.
+func (c *ConnStore) acquireResolved(target string) (*Resource, error) {
+	c.mu.Lock()
+	pool, ok := c.pools[target]
+	var conns []*Resource
+	if ok {
+		conns = append(conns, pool.conns...)
+	}
+	c.mu.Unlock()
+
+	if len(conns) == 0 {
+		return nil, fmt.Errorf("no resolved addresses available for target %q", target)
+	}
+
+	r := c.selector.Next(target, conns)
+	r.beginCall()
+	return r, nil
+}
+
+// SetSelector overrides the load-balancing policy used to pick a
+// connection out of a target's pool.
+func (c *ConnStore) SetSelector(s Selector) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.selector = s
+}
+
+// SetIdlePolicy configures the GC loop's warm floor and idle timeout.
+func (c *ConnStore) SetIdlePolicy(minIdle int, idleTimeout time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if minIdle > 0 {
+		c.minIdle = minIdle
+	}
+	if idleTimeout > 0 {
+		c.idleTimeout = idleTimeout
+	}
+}
+
+// acquire returns a connection for target, dialing a new one via dial when
+// the pool has fewer than poolSize live connections and otherwise handing
+// out one picked by the configured Selector. The returned Resource has
+// already had beginCall called on it; the caller must call endCall once
+// done (see release).
+func (c *ConnStore) acquire(target string, poolSize int, maxLife time.Duration, dial func() (*Resource, error)) (*Resource, error) {
+	if poolSize < 1 {
+		poolSize = 1
+	}
+
+	c.mu.Lock()
+	pool, ok := c.pools[target]
+	if !ok {
+		pool = &connPool{}
+		c.pools[target] = pool
+	}
+	pool.conns = removeInvalid(pool.conns)
+
+	if len(pool.conns) >= poolSize {
+		r := c.selector.Next(target, pool.conns)
+		c.mu.Unlock()
+		r.beginCall()
+		return r, nil
+	}
+	c.mu.Unlock()
+
+	r, err := dial()
+	if err != nil {
+		return nil, err
+	}
+	r.setExpiry(maxLife)
+	r.beginCall()
+
+	c.mu.Lock()
+	pool, ok = c.pools[target]
+	if !ok {
+		pool = &connPool{}
+		c.pools[target] = pool
+	}
+	if len(pool.conns) >= poolSize {
+		// Concurrent callers filled the pool while this one was dialing.
+		existing := c.selector.Next(target, pool.conns)
+		c.mu.Unlock()
+		_ = r.Close()
+		existing.beginCall()
+		return existing, nil
+	}
+	pool.conns = append(pool.conns, r)
+	c.mu.Unlock()
+
+	return r, nil
+}
+
+// release marks an acquired connection as no longer in use.
+func (c *ConnStore) release(r *Resource) {
+	r.endCall()
+}
+
+func removeInvalid(conns []*Resource) []*Resource {
+	kept := conns[:0]
+	for _, r := range conns {
+		if r.isValid() {
+			kept = append(kept, r)
+		} else {
+			_ = r.Close()
+		}
+	}
+	return kept
+}
+
+// getAllConn returns, for every target, its current pool of connections.
+func (c *ConnStore) getAllConn() map[string][]*Resource {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	all := make(map[string][]*Resource, len(c.pools))
+	for target, pool := range c.pools {
+		all[target] = append([]*Resource{}, pool.conns...)
+	}
+	return all
+}
+
+// delete closes and drops every connection pooled for target, and stops
+// following its resolver, if any.
+func (c *ConnStore) delete(target string) {
+	c.mu.Lock()
+	pool, ok := c.pools[target]
+	delete(c.pools, target)
+	if cancel, ok := c.watches[target]; ok {
+		cancel()
+		delete(c.watches, target)
+	}
+	c.mu.Unlock()
+
+	if !ok {
+		return
+	}
+	for _, r := range pool.conns {
+		_ = r.Close()
+	}
+	for _, r := range pool.draining {
+		_ = r.Close()
+	}
+}
+
+// extend pushes out the max-life expiry of every connection pooled for
+// target.
+func (c *ConnStore) extend(target string, maxLife time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pool, ok := c.pools[target]
+	if !ok {
+		return
+	}
+	for _, r := range pool.conns {
+		r.setExpiry(maxLife)
+	}
+}
+
+// StartGC runs reapIdle on every tick until the process exits; nothing
+// stops the loop, and grpcli has historically never needed to stop it
+// mid-process.
+func (c *ConnStore) StartGC(tick time.Duration) {
+	go func() {
+		ticker := time.NewTicker(tick)
+		defer ticker.Stop()
+		for range ticker.C {
+			c.reapIdle()
+		}
+	}()
+}
+
+// reapIdle closes connections that have outlived their TTL and, of those
+// beyond the configured warm floor, any idle longer than the idle timeout.
+// The warm floor does not protect a connection whose TTL has passed. It
+// also closes
+// any draining connection (retired by reconcile) as soon as its last
+// in-flight RPC finishes.
+func (c *ConnStore) reapIdle() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, pool := range c.pools {
+		pool.conns = removeInvalid(pool.conns)
+
+		kept := pool.conns[:0]
+		for i, r := range pool.conns {
+			floorRemaining := len(pool.conns) - i
+			if floorRemaining <= c.minIdle {
+				kept = append(kept, r)
+				continue
+			}
+			if r.idleSince() >= c.idleTimeout {
+				_ = r.Close()
+				continue
+			}
+			kept = append(kept, r)
+		}
+		pool.conns = kept
+
+		draining := pool.draining[:0]
+		for _, r := range pool.draining {
+			if !r.isValid() || r.outstanding() == 0 {
+				_ = r.Close()
+				continue
+			}
+			draining = append(draining, r)
+		}
+		pool.draining = draining
+	}
+}
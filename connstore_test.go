@@ -0,0 +1,112 @@
+package core
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// newTestResource builds a Resource around a real, never-dialed ClientConn
+// so Close is safe to call, without needing a live server.
+func newTestResource(t *testing.T) *Resource {
+	t.Helper()
+	cc, err := grpc.NewClient("passthrough:///test", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Resource{clientConn: cc}
+}
+
+// TestConnStoreAcquireConcurrentWithDelete is a regression test for a
+// nil-pointer panic in acquire: dialing happens outside the lock, and a
+// concurrent delete(target) used to leave the later re-lock indexing a
+// pool that no longer existed.
+func TestConnStoreAcquireConcurrentWithDelete(t *testing.T) {
+	c := NewConnectionStore()
+
+	dial := func() (*Resource, error) {
+		time.Sleep(time.Millisecond)
+		return newTestResource(t), nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = c.acquire("target", 1, 0, dial)
+		}()
+	}
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.delete("target")
+		}()
+	}
+	wg.Wait()
+}
+
+// TestReconcileDrainsBusyConnInsteadOfClosing is a regression test for
+// reconcile tearing down a connection with in-flight RPCs the moment its
+// address drops out of a resolver update: it should move the connection to
+// the pool's draining list and leave it open until reapIdle finds it idle,
+// rather than closing it out from under the caller.
+func TestReconcileDrainsBusyConnInsteadOfClosing(t *testing.T) {
+	c := NewConnectionStore()
+	c.SetIdlePolicy(0, time.Millisecond)
+
+	busy := newTestResource(t)
+	busy.addr = "a:1"
+	busy.beginCall()
+	c.pools["target"] = &connPool{conns: []*Resource{busy}}
+
+	dial := func(addr string) (*Resource, error) { return newTestResource(t), nil }
+
+	// "a:1" drops out of the resolved set.
+	c.reconcile("target", []string{"b:1"}, dial, 0)
+
+	c.mu.Lock()
+	stillOpen := len(c.pools["target"].draining) == 1
+	c.mu.Unlock()
+	if !stillOpen {
+		t.Fatal("reconcile closed a connection with an in-flight RPC instead of draining it")
+	}
+
+	// Once the call finishes, reapIdle should close the drained connection.
+	busy.endCall()
+	c.reapIdle()
+
+	c.mu.Lock()
+	drained := len(c.pools["target"].draining)
+	c.mu.Unlock()
+	if drained != 0 {
+		t.Fatalf("reapIdle left %d draining conns after they went idle, want 0", drained)
+	}
+}
+
+// TestConnStoreReapIdleRespectsFloor checks that reapIdle never closes a
+// pool below its configured warm floor, however long those connections
+// have been idle.
+func TestConnStoreReapIdleRespectsFloor(t *testing.T) {
+	c := NewConnectionStore()
+	c.SetIdlePolicy(1, time.Millisecond)
+
+	pool := &connPool{}
+	for i := 0; i < 3; i++ {
+		r := newTestResource(t)
+		r.endCall()
+		r.idleAt = time.Now().Add(-time.Hour)
+		pool.conns = append(pool.conns, r)
+	}
+	c.pools["target"] = pool
+
+	c.reapIdle()
+
+	if got := len(c.pools["target"].conns); got != 1 {
+		t.Fatalf("reapIdle left %d conns, want 1 (the warm floor)", got)
+	}
+}
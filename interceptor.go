@@ -0,0 +1,257 @@
+package core
+
+import (
+	"context"
+	"log"
+	"math/rand"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// LogLevel controls how much LoggingUnaryInterceptor and
+// LoggingStreamInterceptor write to the standard logger.
+type LogLevel int
+
+const (
+	// LogLevelSilent disables logging entirely.
+	LogLevelSilent LogLevel = iota
+	// LogLevelError logs only failed calls.
+	LogLevelError
+	// LogLevelAll logs every call, success or failure.
+	LogLevelAll
+)
+
+// RetryPolicy configures RetryUnaryInterceptor.
+type RetryPolicy struct {
+	MaxAttempts int
+	Codes       []codes.Code
+	// Backoff returns how long to wait after the given attempt (1-indexed)
+	// fails, before the next one.
+	// Defaults to exponential backoff starting at 100ms when nil.
+	Backoff func(attempt int) time.Duration
+}
+
+func defaultBackoff(attempt int) time.Duration {
+	base := 100 * time.Millisecond
+	d := base << uint(attempt-1)
+	jitter := time.Duration(rand.Int63n(int64(base)))
+	return d + jitter
+}
+
+func (p RetryPolicy) retriable(err error) bool {
+	s, ok := status.FromError(err)
+	if !ok {
+		return false
+	}
+	for _, c := range p.Codes {
+		if s.Code() == c {
+			return true
+		}
+	}
+	return false
+}
+
+// RetryUnaryInterceptor retries a unary call with exponential backoff when
+// it fails with one of policy.Codes, up to policy.MaxAttempts total tries.
+func RetryUnaryInterceptor(policy RetryPolicy) grpc.UnaryClientInterceptor {
+	backoff := policy.Backoff
+	if backoff == nil {
+		backoff = defaultBackoff
+	}
+	maxAttempts := policy.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+
+		var err error
+		for attempt := 1; attempt <= maxAttempts; attempt++ {
+			err = invoker(ctx, method, req, reply, cc, opts...)
+			if err == nil || !policy.retriable(err) || attempt == maxAttempts {
+				return err
+			}
+
+			select {
+			case <-time.After(backoff(attempt)):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		return err
+	}
+}
+
+// LoggingUnaryInterceptor logs each unary call's method, duration and
+// outcome at the given level.
+func LoggingUnaryInterceptor(level LogLevel) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+
+		if level == LogLevelSilent {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		cost := time.Since(start)
+
+		if err != nil {
+			log.Printf("grpcli: %s took %s: %v", method, cost, err)
+		} else if level == LogLevelAll {
+			log.Printf("grpcli: %s took %s", method, cost)
+		}
+		return err
+	}
+}
+
+// LoggingStreamInterceptor mirrors LoggingUnaryInterceptor for streaming
+// calls, logging once the stream is established.
+func LoggingStreamInterceptor(level LogLevel) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string,
+		streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+
+		if level == LogLevelSilent {
+			return streamer(ctx, desc, cc, method, opts...)
+		}
+
+		start := time.Now()
+		s, err := streamer(ctx, desc, cc, method, opts...)
+		cost := time.Since(start)
+
+		if err != nil {
+			log.Printf("grpcli: open stream %s took %s: %v", method, cost, err)
+		} else if level == LogLevelAll {
+			log.Printf("grpcli: open stream %s took %s", method, cost)
+		}
+		return s, err
+	}
+}
+
+// Collector exposes per-method RPC counters, latency histograms and error
+// counts as Prometheus metrics. Register it with a prometheus.Registerer
+// and pair it with MetricsUnaryInterceptor.
+type Collector struct {
+	requests *prometheus.CounterVec
+	errors   *prometheus.CounterVec
+	latency  *prometheus.HistogramVec
+}
+
+// NewCollector builds a Collector; call prometheus.Register(c) (or register
+// with a custom registry) to expose it.
+func NewCollector() *Collector {
+	return &Collector{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "grpcli",
+			Name:      "client_requests_total",
+			Help:      "Total number of unary RPCs made, by method.",
+		}, []string{"method"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "grpcli",
+			Name:      "client_request_errors_total",
+			Help:      "Total number of failed unary RPCs, by method and code.",
+		}, []string{"method", "code"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "grpcli",
+			Name:      "client_request_duration_seconds",
+			Help:      "Unary RPC latency in seconds, by method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method"}),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.requests.Describe(ch)
+	c.errors.Describe(ch)
+	c.latency.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.requests.Collect(ch)
+	c.errors.Collect(ch)
+	c.latency.Collect(ch)
+}
+
+// Collector returns the client's metrics collector, creating one lazily on
+// first call. It does not add MetricsUnaryInterceptor to the client's chain
+// or register the collector with Prometheus.
+func (g *Client) Collector() *Collector {
+	if g.collector == nil {
+		g.collector = NewCollector()
+	}
+	return g.collector
+}
+
+// MetricsUnaryInterceptor records per-method count/latency/error metrics on
+// c for every unary call it wraps.
+func MetricsUnaryInterceptor(c *Collector) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		c.requests.WithLabelValues(method).Inc()
+		c.latency.WithLabelValues(method).Observe(time.Since(start).Seconds())
+		if err != nil {
+			c.errors.WithLabelValues(method, status.Code(err).String()).Inc()
+		}
+		return err
+	}
+}
+
+// TraceUnaryInterceptor starts a client span for each unary call and
+// injects the active trace context into outgoing metadata, so the callee
+// can continue the same trace.
+func TraceUnaryInterceptor(tracer trace.Tracer) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+
+		ctx, span := tracer.Start(ctx, method, trace.WithAttributes(
+			attribute.String("rpc.system", "grpc"),
+			attribute.String("rpc.method", method),
+		))
+		defer span.End()
+
+		md, ok := metadata.FromOutgoingContext(ctx)
+		if !ok {
+			md = metadata.MD{}
+		} else {
+			md = md.Copy()
+		}
+		carrier := propagation.MapCarrier(mdToMap(md))
+		otel.GetTextMapPropagator().Inject(ctx, carrier)
+		for k, v := range carrier {
+			md.Set(k, v)
+		}
+		ctx = metadata.NewOutgoingContext(ctx, md)
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if err != nil {
+			span.RecordError(err)
+		}
+		return err
+	}
+}
+
+func mdToMap(md metadata.MD) map[string]string {
+	m := make(map[string]string, len(md))
+	for k, v := range md {
+		if len(v) > 0 {
+			m[k] = v[0]
+		}
+	}
+	return m
+}
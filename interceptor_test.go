@@ -0,0 +1,146 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// countingInvoker records how many times it was called and returns an
+// Unavailable status error on every call up to failures, then succeeds.
+type countingInvoker struct {
+	failures int
+	calls    int
+}
+
+func (c *countingInvoker) invoke(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+	c.calls++
+	if c.calls <= c.failures {
+		return status.Error(codes.Unavailable, "not yet")
+	}
+	return nil
+}
+
+func noBackoff(attempt int) time.Duration { return 0 }
+
+// TestRetryUnaryInterceptorRetriesRetriableCodes is a regression test for
+// RetryUnaryInterceptor's core contract: it should keep retrying up to
+// MaxAttempts while the invoker returns one of policy.Codes, and succeed as
+// soon as the invoker does.
+func TestRetryUnaryInterceptorRetriesRetriableCodes(t *testing.T) {
+	invoker := &countingInvoker{failures: 2}
+	policy := RetryPolicy{MaxAttempts: 3, Codes: []codes.Code{codes.Unavailable}, Backoff: noBackoff}
+	interceptor := RetryUnaryInterceptor(policy)
+
+	err := interceptor(context.Background(), "/svc/Method", nil, nil, nil, invoker.invoke)
+	if err != nil {
+		t.Fatalf("expected eventual success, got %v", err)
+	}
+	if invoker.calls != 3 {
+		t.Fatalf("invoker called %d times, want 3 (2 failures + 1 success)", invoker.calls)
+	}
+}
+
+// TestRetryUnaryInterceptorStopsOnNonRetriableCode checks that a code not
+// in policy.Codes fails fast instead of being retried.
+func TestRetryUnaryInterceptorStopsOnNonRetriableCode(t *testing.T) {
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		return status.Error(codes.InvalidArgument, "bad request")
+	}
+	policy := RetryPolicy{MaxAttempts: 3, Codes: []codes.Code{codes.Unavailable}, Backoff: noBackoff}
+	interceptor := RetryUnaryInterceptor(policy)
+
+	callCount := 0
+	wrapped := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		callCount++
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+
+	err := interceptor(context.Background(), "/svc/Method", nil, nil, nil, wrapped)
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if callCount != 1 {
+		t.Fatalf("invoker called %d times for a non-retriable code, want 1", callCount)
+	}
+}
+
+// TestRetryUnaryInterceptorExhaustsMaxAttempts checks that a persistently
+// retriable error is given up on after MaxAttempts, returning its error
+// rather than retrying forever.
+func TestRetryUnaryInterceptorExhaustsMaxAttempts(t *testing.T) {
+	invoker := &countingInvoker{failures: 10}
+	policy := RetryPolicy{MaxAttempts: 3, Codes: []codes.Code{codes.Unavailable}, Backoff: noBackoff}
+	interceptor := RetryUnaryInterceptor(policy)
+
+	err := interceptor(context.Background(), "/svc/Method", nil, nil, nil, invoker.invoke)
+	if err == nil {
+		t.Fatal("expected error after exhausting retries, got nil")
+	}
+	if invoker.calls != 3 {
+		t.Fatalf("invoker called %d times, want 3 (MaxAttempts)", invoker.calls)
+	}
+}
+
+// TestTraceUnaryInterceptorInjectsTraceparent is a regression test for
+// 4f480ed: TraceUnaryInterceptor started a span but injected the
+// propagator's carrier into a throwaway map instead of writing it back into
+// the outgoing metadata, so the callee never saw a traceparent header.
+func TestTraceUnaryInterceptorInjectsTraceparent(t *testing.T) {
+	prior := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTextMapPropagator(prior)
+
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSampler(sdktrace.AlwaysSample()))
+	defer tp.Shutdown(context.Background())
+	tracer := tp.Tracer("interceptor_test")
+
+	var gotMD metadata.MD
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		gotMD, _ = metadata.FromOutgoingContext(ctx)
+		return nil
+	}
+
+	interceptor := TraceUnaryInterceptor(tracer)
+	if err := interceptor(context.Background(), "/svc/Method", nil, nil, nil, invoker); err != nil {
+		t.Fatalf("interceptor returned error: %v", err)
+	}
+
+	if got := gotMD.Get("traceparent"); len(got) == 0 || got[0] == "" {
+		t.Fatal("outgoing metadata has no traceparent header")
+	}
+}
+
+// TestTraceUnaryInterceptorRecordsError checks that a failing invoker's
+// error is recorded on the span, not swallowed.
+func TestTraceUnaryInterceptorRecordsError(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSampler(sdktrace.AlwaysSample()), sdktrace.WithSpanProcessor(sr))
+	defer tp.Shutdown(context.Background())
+	tracer := tp.Tracer("interceptor_test")
+
+	wantErr := errors.New("boom")
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		return wantErr
+	}
+
+	interceptor := TraceUnaryInterceptor(tracer)
+	if err := interceptor(context.Background(), "/svc/Method", nil, nil, nil, invoker); err != wantErr {
+		t.Fatalf("got error %v, want %v", err, wantErr)
+	}
+
+	spans := sr.Ended()
+	if len(spans) != 1 || len(spans[0].Events()) == 0 {
+		t.Fatal("error was not recorded on the span")
+	}
+}
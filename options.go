@@ -0,0 +1,90 @@
+package core
+
+import (
+	"time"
+
+	"google.golang.org/grpc/credentials"
+)
+
+// Option configures a Client at construction time; see NewClient.
+type Option func(*Client)
+
+// WithTLS sets the CA certificate, client certificate and client key used
+// for connections dialed with plainText false; it does not itself turn
+// TLS on. Any of the three may be empty: an empty cacert uses the host's
+// root CAs, and an empty cert/key skips client-certificate auth.
+func WithTLS(cacert, cert, key string) Option {
+	return func(g *Client) {
+		g.cacert = cacert
+		g.cert = cert
+		g.key = key
+	}
+}
+
+// WithInsecureSkipVerify dials TLS targets without verifying the server's
+// certificate.
+func WithInsecureSkipVerify() Option {
+	return func(g *Client) {
+		g.insecure = true
+	}
+}
+
+// WithServerNameOverride overrides the server name used for SNI and
+// certificate verification, for targets dialed by IP or behind a proxy
+// that doesn't match the certificate's subject.
+func WithServerNameOverride(name string) Option {
+	return func(g *Client) {
+		g.serverName = name
+	}
+}
+
+// WithAuthority overrides the :authority pseudo-header sent with every
+// call on connections this client dials.
+func WithAuthority(authority string) Option {
+	return func(g *Client) {
+		g.authority = authority
+	}
+}
+
+// WithUnixSocket dials targets over a unix domain socket instead of tcp.
+func WithUnixSocket() Option {
+	return func(g *Client) {
+		g.isUnixSocket = func() bool { return true }
+	}
+}
+
+// WithReflectHeaders sets extra metadata headers that GetResource appends
+// to the client's own headers, so they are sent with every RPC made on the
+// returned resource. The server reflection call used to resolve method
+// descriptors does not carry them.
+func WithReflectHeaders(headers []string) Option {
+	return func(g *Client) {
+		g.reflectHeaders = headers
+	}
+}
+
+// WithMaxLifeConn overrides the MAX_LIFE_CONN env default, setting how
+// long a pooled connection may live before GetResource dials a
+// replacement for it.
+func WithMaxLifeConn(d time.Duration) Option {
+	return func(g *Client) {
+		g.maxLifeConn = d
+	}
+}
+
+// WithKeepAlive sets the gRPC keepalive ping interval and timeout used by
+// every connection this client dials.
+func WithKeepAlive(d time.Duration) Option {
+	return func(g *Client) {
+		g.KeepAlive = d.Seconds()
+	}
+}
+
+// WithPerRPCCredentials attaches creds - e.g. an OAuth2 token source or a
+// hand-rolled JWT bearer credential - to every call via
+// grpc.WithPerRPCCredentials.
+func WithPerRPCCredentials(creds credentials.PerRPCCredentials) Option {
+	return func(g *Client) {
+		g.perRPCCreds = creds
+	}
+}
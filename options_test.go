@@ -0,0 +1,67 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestOptionsApplyToClient checks that each functional Option sets the
+// Client field it documents, since NewClient applies them with no other
+// coverage today.
+func TestOptionsApplyToClient(t *testing.T) {
+	g := NewClient(
+		WithTLS("ca.pem", "cert.pem", "key.pem"),
+		WithInsecureSkipVerify(),
+		WithServerNameOverride("override.example.com"),
+		WithAuthority("authority.example.com"),
+		WithUnixSocket(),
+		WithReflectHeaders([]string{"x-reflect: 1"}),
+		WithMaxLifeConn(5*time.Minute),
+		WithKeepAlive(30*time.Second),
+	)
+
+	if g.cacert != "ca.pem" || g.cert != "cert.pem" || g.key != "key.pem" {
+		t.Fatalf("WithTLS not applied: cacert=%q cert=%q key=%q", g.cacert, g.cert, g.key)
+	}
+	if !g.insecure {
+		t.Fatal("WithInsecureSkipVerify not applied")
+	}
+	if g.serverName != "override.example.com" {
+		t.Fatalf("WithServerNameOverride not applied: %q", g.serverName)
+	}
+	if g.authority != "authority.example.com" {
+		t.Fatalf("WithAuthority not applied: %q", g.authority)
+	}
+	if g.isUnixSocket == nil || !g.isUnixSocket() {
+		t.Fatal("WithUnixSocket not applied")
+	}
+	if len(g.reflectHeaders) != 1 || g.reflectHeaders[0] != "x-reflect: 1" {
+		t.Fatalf("WithReflectHeaders not applied: %v", g.reflectHeaders)
+	}
+	if g.maxLifeConn != 5*time.Minute {
+		t.Fatalf("WithMaxLifeConn not applied: %v", g.maxLifeConn)
+	}
+	if g.KeepAlive != 30 {
+		t.Fatalf("WithKeepAlive not applied: %v", g.KeepAlive)
+	}
+}
+
+// TestWithPerRPCCredentialsApplied checks WithPerRPCCredentials separately
+// since it needs a credentials.PerRPCCredentials value, not a primitive.
+func TestWithPerRPCCredentialsApplied(t *testing.T) {
+	creds := fakePerRPCCreds{}
+	g := NewClient(WithPerRPCCredentials(creds))
+
+	if g.perRPCCreds != creds {
+		t.Fatal("WithPerRPCCredentials not applied")
+	}
+}
+
+type fakePerRPCCreds struct{}
+
+func (fakePerRPCCreds) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return nil, nil
+}
+
+func (fakePerRPCCreds) RequireTransportSecurity() bool { return false }
@@ -0,0 +1,211 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// ProtoRegistry compiles uploaded .proto sources into a
+// descriptorpb.FileDescriptorSet once and caches the result, keyed by the
+// sha256 digest of the protos' content, both in memory and on disk under
+// dir. Multiple Resources attached to the same digest share the compiled
+// descriptors instead of each re-parsing them.
+type ProtoRegistry struct {
+	mu        sync.Mutex
+	entries   map[string]*descriptorpb.FileDescriptorSet
+	aliases   map[string]string
+	compiling map[string]*compileJob
+	dir       string
+}
+
+// compileJob tracks a single in-progress Register call so concurrent
+// callers for the same digest can wait on it and learn whether it
+// succeeded.
+type compileJob struct {
+	wg  sync.WaitGroup
+	err error
+}
+
+// NewProtoRegistry builds a registry that persists compiled
+// FileDescriptorSets as "<digest>.fds" files under dir.
+func NewProtoRegistry(dir string) *ProtoRegistry {
+	return &ProtoRegistry{
+		entries:   make(map[string]*descriptorpb.FileDescriptorSet),
+		aliases:   make(map[string]string),
+		compiling: make(map[string]*compileJob),
+		dir:       dir,
+	}
+}
+
+// Digest returns the content-addressed key for protos: the hex sha256 of
+// each proto's content, combined in the given order.
+func Digest(protos []Proto) string {
+	h := sha256.New()
+	for _, p := range protos {
+		sum := sha256.Sum256(p.Content)
+		h.Write(sum[:])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Register compiles protos into a FileDescriptorSet - reusing the
+// in-memory or on-disk cache when the content digest is already known -
+// and records name as an alias for it. name may be empty to skip
+// aliasing.
+func (reg *ProtoRegistry) Register(name string, protos []Proto) (digest string, err error) {
+	digest = Digest(protos)
+
+	// Only the first caller for a given digest compiles it; concurrent
+	// callers for the same uncached digest wait on that compile instead of
+	// each re-parsing and re-persisting the same protos.
+	reg.mu.Lock()
+	_, cached := reg.entries[digest]
+	job, inProgress := reg.compiling[digest]
+	if !cached && !inProgress {
+		job = new(compileJob)
+		job.wg.Add(1)
+		reg.compiling[digest] = job
+	}
+	reg.mu.Unlock()
+
+	switch {
+	case cached:
+		// nothing to do
+	case inProgress:
+		job.wg.Wait()
+		if job.err != nil {
+			return "", job.err
+		}
+	default:
+		defer func() {
+			reg.mu.Lock()
+			delete(reg.compiling, digest)
+			reg.mu.Unlock()
+			job.wg.Done()
+		}()
+
+		if fds, loadErr := reg.loadCached(digest); loadErr == nil {
+			reg.mu.Lock()
+			reg.entries[digest] = fds
+			reg.mu.Unlock()
+			break
+		}
+
+		fds, compileErr := compile(protos)
+		if compileErr != nil {
+			job.err = compileErr
+			return "", compileErr
+		}
+
+		reg.mu.Lock()
+		reg.entries[digest] = fds
+		reg.mu.Unlock()
+
+		if err := reg.persist(digest, fds); err != nil {
+			job.err = err
+			return digest, err
+		}
+	}
+
+	if name != "" {
+		reg.mu.Lock()
+		reg.aliases[name] = digest
+		reg.mu.Unlock()
+	}
+
+	return digest, nil
+}
+
+// Get returns the cached FileDescriptorSet for digest, if known.
+func (reg *ProtoRegistry) Get(digest string) (*descriptorpb.FileDescriptorSet, bool) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	fds, ok := reg.entries[digest]
+	return fds, ok
+}
+
+// Resolve looks up the digest that name was last registered under.
+func (reg *ProtoRegistry) Resolve(name string) (digest string, ok bool) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	digest, ok = reg.aliases[name]
+	return digest, ok
+}
+
+// compile writes protos to protoDir and parses them into a deduplicated
+// FileDescriptorSet, following each file's dependencies.
+func compile(protos []Proto) (*descriptorpb.FileDescriptorSet, error) {
+	if err := os.MkdirAll(protoDir, 0o755); err != nil {
+		return nil, fmt.Errorf("create proto dir: %w", err)
+	}
+
+	files := make([]string, len(protos))
+	for i, p := range protos {
+		files[i] = p.Name
+		if err := ioutil.WriteFile(filepath.Join(protoDir, p.Name), p.Content, 0o644); err != nil {
+			return nil, fmt.Errorf("persist proto %s: %w", p.Name, err)
+		}
+	}
+
+	parser := protoparse.Parser{ImportPaths: []string{protoDir}}
+	parsed, err := parser.ParseFiles(files...)
+	if err != nil {
+		return nil, fmt.Errorf("parse protos: %w", err)
+	}
+
+	fds := &descriptorpb.FileDescriptorSet{}
+	seen := make(map[string]bool)
+	var add func(fd *desc.FileDescriptor)
+	add = func(fd *desc.FileDescriptor) {
+		if seen[fd.GetName()] {
+			return
+		}
+		seen[fd.GetName()] = true
+		for _, dep := range fd.GetDependencies() {
+			add(dep)
+		}
+		fds.File = append(fds.File, fd.AsFileDescriptorProto())
+	}
+	for _, fd := range parsed {
+		add(fd)
+	}
+
+	return fds, nil
+}
+
+func (reg *ProtoRegistry) cachePath(digest string) string {
+	return filepath.Join(reg.dir, digest+".fds")
+}
+
+func (reg *ProtoRegistry) loadCached(digest string) (*descriptorpb.FileDescriptorSet, error) {
+	data, err := ioutil.ReadFile(reg.cachePath(digest))
+	if err != nil {
+		return nil, err
+	}
+	fds := &descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(data, fds); err != nil {
+		return nil, err
+	}
+	return fds, nil
+}
+
+func (reg *ProtoRegistry) persist(digest string, fds *descriptorpb.FileDescriptorSet) error {
+	if err := os.MkdirAll(reg.dir, 0o755); err != nil {
+		return fmt.Errorf("create registry cache dir: %w", err)
+	}
+	data, err := proto.Marshal(fds)
+	if err != nil {
+		return fmt.Errorf("marshal descriptor set: %w", err)
+	}
+	return ioutil.WriteFile(reg.cachePath(digest), data, 0o644)
+}
@@ -0,0 +1,71 @@
+package core
+
+import (
+	"sync"
+	"testing"
+)
+
+// TestProtoRegistryRegisterPropagatesCompileError is a regression test for
+// concurrent waiters on a failing compile getting back (digest, nil) as if
+// it had succeeded. protoparse rejects the malformed proto below, so every
+// concurrent Register call for it must return an error.
+func TestProtoRegistryRegisterPropagatesCompileError(t *testing.T) {
+	dir := t.TempDir()
+	reg := NewProtoRegistry(dir)
+	protoDir = t.TempDir()
+
+	protos := []Proto{{Name: "bad.proto", Content: []byte("this is not valid proto syntax {{{")}}
+
+	var wg sync.WaitGroup
+	errs := make([]error, 8)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = reg.Register("", protos)
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err == nil {
+			t.Errorf("Register[%d] = nil error, want a compile error", i)
+		}
+	}
+}
+
+// TestProtoRegistryRegisterDedupesCompile checks that concurrent Register
+// calls for the same valid digest share one compile and all succeed with
+// the same digest.
+func TestProtoRegistryRegisterDedupesCompile(t *testing.T) {
+	dir := t.TempDir()
+	reg := NewProtoRegistry(dir)
+	protoDir = t.TempDir()
+
+	protos := []Proto{{Name: "ok.proto", Content: []byte(`syntax = "proto3"; package ok; message M { string f = 1; }`)}}
+
+	var wg sync.WaitGroup
+	digests := make([]string, 8)
+	errs := make([]error, 8)
+	for i := range digests {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			digests[i], errs[i] = reg.Register("", protos)
+		}(i)
+	}
+	wg.Wait()
+
+	want := Digest(protos)
+	for i := range digests {
+		if errs[i] != nil {
+			t.Fatalf("Register[%d]: %v", i, errs[i])
+		}
+		if digests[i] != want {
+			t.Fatalf("Register[%d] = %q, want %q", i, digests[i], want)
+		}
+	}
+	if _, ok := reg.Get(want); !ok {
+		t.Fatalf("Get(%q) not found after Register", want)
+	}
+}
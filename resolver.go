@@ -0,0 +1,142 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Resolver turns a logical target such as "dns:///myservice",
+// "static:///a:1,b:2,c:3" or "consul://foo" into a set of dialable
+// host:port addresses, and keeps pushing the full updated set as it
+// changes for as long as ctx is alive.
+type Resolver interface {
+	Resolve(ctx context.Context, target string) (addrs []string, updates <-chan []string, err error)
+}
+
+var resolverRegistry = struct {
+	mu       sync.RWMutex
+	byScheme map[string]Resolver
+}{byScheme: make(map[string]Resolver)}
+
+// RegisterResolver makes r available for targets of the form
+// "<scheme>://...". Registering under a scheme that's already taken
+// replaces it. dns and static are registered by default.
+func RegisterResolver(scheme string, r Resolver) {
+	resolverRegistry.mu.Lock()
+	defer resolverRegistry.mu.Unlock()
+	resolverRegistry.byScheme[scheme] = r
+}
+
+func resolverFor(scheme string) (Resolver, bool) {
+	resolverRegistry.mu.RLock()
+	defer resolverRegistry.mu.RUnlock()
+	r, ok := resolverRegistry.byScheme[scheme]
+	return r, ok
+}
+
+func init() {
+	RegisterResolver("dns", NewDNSResolver(30*time.Second))
+	RegisterResolver("static", StaticResolver{})
+}
+
+// splitScheme splits a target like "dns:///myservice" into ("dns",
+// "myservice"). A target with no "scheme://" prefix is returned as-is with
+// an empty scheme, signaling a plain host:port that bypasses resolution.
+func splitScheme(target string) (scheme, endpoint string) {
+	parts := strings.SplitN(target, "://", 2)
+	if len(parts) != 2 {
+		return "", target
+	}
+	return parts[0], strings.TrimPrefix(parts[1], "/")
+}
+
+// DNSResolver resolves "dns:///host[:port]" targets by periodically
+// calling net.LookupHost, defaulting to port 443 when the host carries
+// none, and pushing the refreshed address set on every poll.
+type DNSResolver struct {
+	interval time.Duration
+}
+
+// NewDNSResolver builds a DNSResolver that re-polls DNS every interval
+// (defaulting to 30s when non-positive).
+func NewDNSResolver(interval time.Duration) *DNSResolver {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	return &DNSResolver{interval: interval}
+}
+
+// Resolve implements Resolver.
+func (d *DNSResolver) Resolve(ctx context.Context, target string) ([]string, <-chan []string, error) {
+	_, endpoint := splitScheme(target)
+	host, port, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		host, port = endpoint, "443"
+	}
+
+	lookup := func() ([]string, error) {
+		ips, err := net.DefaultResolver.LookupHost(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		addrs := make([]string, len(ips))
+		for i, ip := range ips {
+			addrs[i] = net.JoinHostPort(ip, port)
+		}
+		return addrs, nil
+	}
+
+	initial, err := lookup()
+	if err != nil {
+		return nil, nil, fmt.Errorf("dns resolve %s: %w", host, err)
+	}
+
+	updates := make(chan []string)
+	go func() {
+		defer close(updates)
+		ticker := time.NewTicker(d.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				addrs, err := lookup()
+				if err != nil {
+					continue
+				}
+				select {
+				case updates <- addrs:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return initial, updates, nil
+}
+
+// StaticResolver resolves "static:///addr1,addr2,..." targets to a fixed,
+// comma-separated address list that never changes.
+type StaticResolver struct{}
+
+// Resolve implements Resolver.
+func (StaticResolver) Resolve(ctx context.Context, target string) ([]string, <-chan []string, error) {
+	_, endpoint := splitScheme(target)
+	if endpoint == "" {
+		return nil, nil, fmt.Errorf("static resolver: empty target %q", target)
+	}
+
+	updates := make(chan []string)
+	go func() {
+		<-ctx.Done()
+		close(updates)
+	}()
+
+	return strings.Split(endpoint, ","), updates, nil
+}
@@ -0,0 +1,107 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingResolver counts concurrent Resolve calls and holds each one open
+// briefly, long enough that two callers racing on the same new target would
+// both be inside Resolve at once if ensureResolved failed to serialize them.
+type countingResolver struct {
+	addrs []string
+	calls int32
+}
+
+func (r *countingResolver) Resolve(ctx context.Context, target string) ([]string, <-chan []string, error) {
+	atomic.AddInt32(&r.calls, 1)
+	time.Sleep(20 * time.Millisecond)
+	updates := make(chan []string)
+	go func() {
+		<-ctx.Done()
+		close(updates)
+	}()
+	return r.addrs, updates, nil
+}
+
+// TestEnsureResolvedSingleWatchPerTarget is a regression test for a race in
+// ensureResolved: two concurrent callers for the same brand-new target used
+// to both pass the "already watching" check before the slow Resolve call,
+// each starting its own resolver goroutine and leaking all but the last
+// one's cancel func.
+func TestEnsureResolvedSingleWatchPerTarget(t *testing.T) {
+	c := NewConnectionStore()
+	resolver := &countingResolver{addrs: []string{"a:1"}}
+	dial := func(addr string) (*Resource, error) {
+		return newTestResource(t), nil
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = c.ensureResolved("svc://target", resolver, dial, 0)
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("ensureResolved[%d]: %v", i, err)
+		}
+	}
+
+	if got := atomic.LoadInt32(&resolver.calls); got != 1 {
+		t.Fatalf("resolver.Resolve was called %d times for one target, want 1", got)
+	}
+
+	c.mu.Lock()
+	watchCount := len(c.watches)
+	c.mu.Unlock()
+	if watchCount != 1 {
+		t.Fatalf("got %d watches for one target, want 1", watchCount)
+	}
+}
+
+// TestEnsureResolvedPoolReadyForEveryCaller is a regression test for
+// ensureResolved marking a target as watched before reconcile populated its
+// pool: a concurrent caller that saw "already watching" could then call
+// acquireResolved against a still-empty pool and fail with "no resolved
+// addresses available", even though its own ensureResolved call returned
+// nil.
+func TestEnsureResolvedPoolReadyForEveryCaller(t *testing.T) {
+	c := NewConnectionStore()
+	resolver := &countingResolver{addrs: []string{"a:1"}}
+	dial := func(addr string) (*Resource, error) {
+		return newTestResource(t), nil
+	}
+
+	const n = 20
+	var wg sync.WaitGroup
+	errs := make([]error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := c.ensureResolved("svc://target", resolver, dial, 0); err != nil {
+				errs[i] = err
+				return
+			}
+			if _, err := c.acquireResolved("svc://target"); err != nil {
+				errs[i] = err
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("caller %d: %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/fullstorydev/grpcurl"
+	"github.com/jhump/protoreflect/grpcreflect"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	reflectpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// protoDir is where uploaded .proto sources are persisted so that a
+// resource can be reopened against the same schema without re-uploading.
+var protoDir = envOr("PROTO_DIR", "./protos")
+
+// Resource represents a single live connection to a grpc target, plus
+// whatever reflection headers / uploaded protos are needed to describe
+// the services it exposes.
+type Resource struct {
+	clientConn *grpc.ClientConn
+	md         metadata.MD
+	headers    []string
+	protos     []Proto
+
+	// digest and descFDS identify and hold the compiled schema shared via
+	// a ProtoRegistry, set via attachDigest by GetResourceWithProto and
+	// GetResourceWithProtoDigest. descFDS takes
+	// priority over protos in descSource, since it's already parsed.
+	digest  string
+	descFDS *descriptorpb.FileDescriptorSet
+
+	// addr is the concrete host:port this connection was dialed to; set
+	// only when the resource was opened against a resolver-backed logical
+	// target, so reconciliation can tell which resolved address it is.
+	addr string
+
+	expiresAt time.Time
+	idleAt    time.Time
+	inFlight  int32
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// isValid reports whether the underlying connection is still usable, i.e.
+// it has not outlived its configured max life.
+func (r *Resource) isValid() bool {
+	if r.expiresAt.IsZero() {
+		return true
+	}
+	return time.Now().Before(r.expiresAt)
+}
+
+// setExpiry sets the connection's max life, or leaves it immortal when
+// maxLife is zero.
+func (r *Resource) setExpiry(maxLife time.Duration) {
+	if maxLife > 0 {
+		r.expiresAt = time.Now().Add(maxLife)
+	}
+}
+
+// beginCall marks the resource as having one more RPC in flight; pair it
+// with endCall once the call completes.
+func (r *Resource) beginCall() {
+	atomic.AddInt32(&r.inFlight, 1)
+}
+
+// endCall marks an in-flight RPC as finished and records the resource as
+// idle as of now, for the pool's idle-reaping GC.
+func (r *Resource) endCall() {
+	atomic.AddInt32(&r.inFlight, -1)
+	r.idleAt = time.Now()
+}
+
+// outstanding returns the number of RPCs currently in flight on this
+// connection, used by load-balancing Selectors such as
+// LeastOutstandingSelector.
+func (r *Resource) outstanding() int32 {
+	return atomic.LoadInt32(&r.inFlight)
+}
+
+// idleSince reports how long the resource has had zero in-flight RPCs.
+func (r *Resource) idleSince() time.Duration {
+	if r.outstanding() > 0 || r.idleAt.IsZero() {
+		return 0
+	}
+	return time.Since(r.idleAt)
+}
+
+// Close tears down the underlying connection.
+func (r *Resource) Close() error {
+	return r.clientConn.Close()
+}
+
+// descSource builds the grpcurl descriptor source used to resolve methods:
+// a registry-compiled FileDescriptorSet beats re-parsing raw protos, which
+// in turn beats falling back to server reflection.
+func (r *Resource) descSource(ctx context.Context) (grpcurl.DescriptorSource, error) {
+	if r.descFDS != nil {
+		return grpcurl.DescriptorSourceFromFileDescriptorSet(r.descFDS)
+	}
+
+	if len(r.protos) > 0 {
+		files := make([]string, len(r.protos))
+		for i, p := range r.protos {
+			files[i] = p.Name
+		}
+		return grpcurl.DescriptorSourceFromProtoFiles([]string{protoDir}, files...)
+	}
+
+	rc := grpcreflect.NewClientV1Alpha(ctx, reflectpb.NewServerReflectionClient(r.clientConn))
+	return grpcurl.DescriptorSourceFromServer(ctx, rc), nil
+}
+
+// attachDigest shares a ProtoRegistry-compiled schema with this resource,
+// skipping the swap if it's already attached to the same digest.
+func (r *Resource) attachDigest(digest string, fds *descriptorpb.FileDescriptorSet) {
+	if r.digest == digest {
+		return
+	}
+	r.digest = digest
+	r.descFDS = fds
+}
+
+// AddProtos attaches the given protofiles to the resource and persists them
+// to disk under protoDir so subsequent resources can reuse them without a
+// fresh upload.
+func (r *Resource) AddProtos(protos []Proto) error {
+	if err := os.MkdirAll(protoDir, 0o755); err != nil {
+		return fmt.Errorf("create proto dir: %w", err)
+	}
+
+	for _, p := range protos {
+		path := filepath.Join(protoDir, p.Name)
+		if err := ioutil.WriteFile(path, p.Content, 0o644); err != nil {
+			return fmt.Errorf("persist proto %s: %w", p.Name, err)
+		}
+	}
+
+	r.protos = protos
+	return nil
+}
+
+// Invoke performs a single unary RPC against method (formatted as
+// "package.Service.Method") with the given JSON-encoded request payload,
+// returning the JSON-encoded reply and how long the call took.
+func (r *Resource) Invoke(ctx context.Context, method string, data *bytes.Buffer) (
+	reply string, cost time.Duration, err error) {
+
+	source, err := r.descSource(ctx)
+	if err != nil {
+		return "", 0, fmt.Errorf("resolve descriptor source: %w", err)
+	}
+
+	out := new(bytes.Buffer)
+	rf, formatter, err := grpcurl.RequestParserAndFormatter(grpcurl.FormatJSON, source, data, grpcurl.FormatOptions{})
+	if err != nil {
+		return "", 0, fmt.Errorf("build request parser: %w", err)
+	}
+	handler := grpcurl.NewDefaultEventHandler(out, source, formatter, false)
+
+	start := time.Now()
+	ctx = metadata.NewOutgoingContext(ctx, r.md)
+	err = grpcurl.InvokeRPC(ctx, source, r.clientConn, method, r.headers, handler, rf.Next)
+	cost = time.Since(start)
+	if err != nil {
+		return "", cost, fmt.Errorf("invoke %s: %w", method, err)
+	}
+	if handler.Status.Err() != nil {
+		return "", cost, fmt.Errorf("invoke %s: %w", method, handler.Status.Err())
+	}
+
+	return out.String(), cost, nil
+}
@@ -0,0 +1,67 @@
+package core
+
+import (
+	"math/rand"
+	"sync"
+)
+
+// Selector picks which of a target's pooled connections should serve the
+// next call. Implementations must be safe for concurrent use.
+type Selector interface {
+	Next(target string, conns []*Resource) *Resource
+}
+
+// RoundRobinSelector cycles through a target's connections in order.
+type RoundRobinSelector struct {
+	mu      sync.Mutex
+	cursors map[string]uint64
+}
+
+// NewRoundRobinSelector builds a RoundRobinSelector.
+func NewRoundRobinSelector() *RoundRobinSelector {
+	return &RoundRobinSelector{cursors: make(map[string]uint64)}
+}
+
+// Next implements Selector.
+func (s *RoundRobinSelector) Next(target string, conns []*Resource) *Resource {
+	if len(conns) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	i := s.cursors[target]
+	s.cursors[target] = i + 1
+	s.mu.Unlock()
+
+	return conns[i%uint64(len(conns))]
+}
+
+// LeastOutstandingSelector picks the connection with the fewest in-flight
+// RPCs, favoring idle connections under load.
+type LeastOutstandingSelector struct{}
+
+// Next implements Selector.
+func (LeastOutstandingSelector) Next(target string, conns []*Resource) *Resource {
+	if len(conns) == 0 {
+		return nil
+	}
+
+	best := conns[0]
+	for _, c := range conns[1:] {
+		if c.outstanding() < best.outstanding() {
+			best = c
+		}
+	}
+	return best
+}
+
+// RandomSelector picks a connection uniformly at random.
+type RandomSelector struct{}
+
+// Next implements Selector.
+func (RandomSelector) Next(target string, conns []*Resource) *Resource {
+	if len(conns) == 0 {
+		return nil
+	}
+	return conns[rand.Intn(len(conns))]
+}
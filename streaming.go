@@ -0,0 +1,217 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/fullstorydev/grpcurl"
+	"github.com/golang/protobuf/proto"
+	"github.com/jhump/protoreflect/desc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// StreamEvent is emitted on the channel returned by the Invoke*Stream
+// family. A reply event carries Reply plus any Headers received so far.
+// When the call completes, the final event on the channel carries Status
+// (possibly OK) along with whatever Headers/Trailers the server sent, and
+// has Reply unset. When resolving the method or invoking it fails, the
+// final event instead carries only Err.
+type StreamEvent struct {
+	Reply    string
+	Headers  metadata.MD
+	Trailers metadata.MD
+	Status   *status.Status
+	Err      error
+}
+
+// streamEventHandler adapts grpcurl's InvocationEventHandler onto the
+// channel-based Invoke*Stream API, forwarding each decoded response as a
+// StreamEvent and recording headers/trailers/status for the final event.
+type streamEventHandler struct {
+	out       chan<- StreamEvent
+	formatter grpcurl.Formatter
+	headers   metadata.MD
+	trailers  metadata.MD
+	status    *status.Status
+}
+
+func (h *streamEventHandler) OnResolveMethod(*desc.MethodDescriptor) {}
+
+func (h *streamEventHandler) OnSendHeaders(metadata.MD) {}
+
+func (h *streamEventHandler) OnReceiveHeaders(md metadata.MD) {
+	h.headers = md
+}
+
+func (h *streamEventHandler) OnReceiveResponse(m proto.Message) {
+	reply, err := h.formatter(m)
+	if err != nil {
+		h.out <- StreamEvent{Err: fmt.Errorf("format response: %w", err)}
+		return
+	}
+	h.out <- StreamEvent{Reply: reply, Headers: h.headers}
+}
+
+func (h *streamEventHandler) OnReceiveTrailers(s *status.Status, md metadata.MD) {
+	h.trailers = md
+	h.status = s
+}
+
+// invokeStream drives method through grpcurl's generic RPC invocation,
+// which dispatches to the right wire behaviour (unary, client-stream,
+// server-stream, bidi) based on the resolved method descriptor. Requests
+// are read off reqs and written, JSON-encoded, into a pipe that grpcurl's
+// request parser consumes one message at a time; closing reqs signals
+// CloseSend. sendTimeout, if non-zero, bounds each individual write.
+func (r *Resource) invokeStream(ctx context.Context, method string, reqs <-chan *bytes.Buffer, sendTimeout time.Duration) <-chan StreamEvent {
+	out := make(chan StreamEvent)
+
+	pr, pw := io.Pipe()
+	go func() {
+		defer pw.Close()
+		for {
+			select {
+			case <-ctx.Done():
+				pw.CloseWithError(ctx.Err())
+				return
+			case msg, ok := <-reqs:
+				if !ok {
+					return
+				}
+
+				writeCtx := ctx
+				var cancel context.CancelFunc
+				if sendTimeout > 0 {
+					writeCtx, cancel = context.WithTimeout(ctx, sendTimeout)
+				}
+
+				done := make(chan error, 1)
+				go func() { _, err := pw.Write(msg.Bytes()); done <- err }()
+
+				select {
+				case err := <-done:
+					if cancel != nil {
+						cancel()
+					}
+					if err != nil {
+						pw.CloseWithError(err)
+						return
+					}
+				case <-writeCtx.Done():
+					if cancel != nil {
+						cancel()
+					}
+					pw.CloseWithError(writeCtx.Err())
+					return
+				}
+			}
+		}
+	}()
+
+	go func() {
+		defer close(out)
+
+		source, err := r.descSource(ctx)
+		if err != nil {
+			out <- StreamEvent{Err: fmt.Errorf("resolve descriptor source: %w", err)}
+			return
+		}
+
+		rf, formatter, err := grpcurl.RequestParserAndFormatter(grpcurl.FormatJSON, source, pr, grpcurl.FormatOptions{})
+		if err != nil {
+			out <- StreamEvent{Err: fmt.Errorf("build request parser: %w", err)}
+			return
+		}
+		handler := &streamEventHandler{out: out, formatter: formatter}
+
+		callCtx := metadata.NewOutgoingContext(ctx, r.md)
+		err = grpcurl.InvokeRPC(callCtx, source, r.clientConn, method, r.headers, handler, rf.Next)
+		if err != nil && err != io.EOF {
+			out <- StreamEvent{Err: fmt.Errorf("invoke %s: %w", method, err)}
+			return
+		}
+
+		out <- StreamEvent{
+			Headers:  handler.headers,
+			Trailers: handler.trailers,
+			Status:   handler.status,
+		}
+	}()
+
+	return out
+}
+
+// InvokeClientStream performs a client-streaming RPC: it sends each
+// JSON-encoded request off reqs until the channel is closed, then the
+// returned channel yields a StreamEvent carrying the server's reply,
+// followed by a final event carrying trailers and status.
+func (r *Resource) InvokeClientStream(ctx context.Context, method string, reqs <-chan *bytes.Buffer, sendTimeout time.Duration) <-chan StreamEvent {
+	return r.invokeStream(ctx, method, reqs, sendTimeout)
+}
+
+// InvokeServerStream performs a server-streaming RPC: it sends the single
+// JSON-encoded request and the returned channel yields one StreamEvent per
+// reply as the server produces them, followed by a final event carrying
+// trailers and status.
+func (r *Resource) InvokeServerStream(ctx context.Context, method string, req *bytes.Buffer) <-chan StreamEvent {
+	reqs := make(chan *bytes.Buffer, 1)
+	reqs <- req
+	close(reqs)
+	return r.invokeStream(ctx, method, reqs, 0)
+}
+
+// InvokeBidiStream performs a bidirectional-streaming RPC: each message off
+// reqs is sent as soon as it's available and a StreamEvent is emitted for
+// every reply, interleaved in whatever order the server produces them;
+// closing reqs signals CloseSend.
+func (r *Resource) InvokeBidiStream(ctx context.Context, method string, reqs <-chan *bytes.Buffer, sendTimeout time.Duration) <-chan StreamEvent {
+	return r.invokeStream(ctx, method, reqs, sendTimeout)
+}
+
+// releaseAfter forwards every event off in to a new channel, releasing rs
+// back to its pool once in is drained (i.e. the stream has ended).
+func (g *Client) releaseAfter(rs *Resource, in <-chan StreamEvent) <-chan StreamEvent {
+	out := make(chan StreamEvent)
+	go func() {
+		defer close(out)
+		defer g.activeConn.release(rs)
+		for ev := range in {
+			out <- ev
+		}
+	}()
+	return out
+}
+
+// InvokeClientStream opens target and performs a client-streaming RPC
+// against service.method. See Resource.InvokeClientStream.
+func (g *Client) InvokeClientStream(ctx context.Context, target, service, method string, reqs <-chan *bytes.Buffer, sendTimeout time.Duration) (<-chan StreamEvent, error) {
+	rs, err := g.GetResource(ctx, target, true, false)
+	if err != nil {
+		return nil, err
+	}
+	return g.releaseAfter(rs, rs.InvokeClientStream(ctx, fmt.Sprintf("%s.%s", service, method), reqs, sendTimeout)), nil
+}
+
+// InvokeServerStream opens target and performs a server-streaming RPC
+// against service.method. See Resource.InvokeServerStream.
+func (g *Client) InvokeServerStream(ctx context.Context, target, service, method string, req *bytes.Buffer) (<-chan StreamEvent, error) {
+	rs, err := g.GetResource(ctx, target, true, false)
+	if err != nil {
+		return nil, err
+	}
+	return g.releaseAfter(rs, rs.InvokeServerStream(ctx, fmt.Sprintf("%s.%s", service, method), req)), nil
+}
+
+// InvokeBidiStream opens target and performs a bidirectional-streaming RPC
+// against service.method. See Resource.InvokeBidiStream.
+func (g *Client) InvokeBidiStream(ctx context.Context, target, service, method string, reqs <-chan *bytes.Buffer, sendTimeout time.Duration) (<-chan StreamEvent, error) {
+	rs, err := g.GetResource(ctx, target, true, false)
+	if err != nil {
+		return nil, err
+	}
+	return g.releaseAfter(rs, rs.InvokeBidiStream(ctx, fmt.Sprintf("%s.%s", service, method), reqs, sendTimeout)), nil
+}
@@ -0,0 +1,82 @@
+package core
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// TestStreamEventHandlerOnReceiveResponse is a regression test for the
+// chunk0-1 compile break: streamEventHandler.formatter must be a
+// grpcurl.Formatter (a func, not a struct with a Format method), and the
+// proto.Message it's called with must satisfy the legacy
+// github.com/golang/protobuf/proto.Message interface grpcurl's
+// InvocationEventHandler requires.
+func TestStreamEventHandlerOnReceiveResponse(t *testing.T) {
+	out := make(chan StreamEvent, 1)
+	h := &streamEventHandler{
+		out: out,
+		formatter: func(m proto.Message) (string, error) {
+			return "formatted", nil
+		},
+		headers: metadata.MD{"k": []string{"v"}},
+	}
+
+	h.OnReceiveResponse(&emptypb.Empty{})
+
+	ev := <-out
+	if ev.Reply != "formatted" {
+		t.Fatalf("got reply %q, want %q", ev.Reply, "formatted")
+	}
+	if ev.Err != nil {
+		t.Fatalf("unexpected error: %v", ev.Err)
+	}
+	if got := ev.Headers.Get("k"); len(got) != 1 || got[0] != "v" {
+		t.Fatalf("reply event didn't carry headers recorded by OnReceiveHeaders, got %v", ev.Headers)
+	}
+}
+
+// TestStreamEventHandlerOnReceiveResponseFormatError checks that a
+// formatter error surfaces as a StreamEvent.Err instead of panicking or
+// being swallowed.
+func TestStreamEventHandlerOnReceiveResponseFormatError(t *testing.T) {
+	out := make(chan StreamEvent, 1)
+	wantErr := errors.New("bad format")
+	h := &streamEventHandler{
+		out: out,
+		formatter: func(m proto.Message) (string, error) {
+			return "", wantErr
+		},
+	}
+
+	h.OnReceiveResponse(&emptypb.Empty{})
+
+	ev := <-out
+	if ev.Err == nil {
+		t.Fatal("expected non-nil Err when formatter fails")
+	}
+	if ev.Reply != "" {
+		t.Fatalf("expected empty Reply on format error, got %q", ev.Reply)
+	}
+}
+
+// TestStreamEventHandlerOnReceiveTrailers checks that trailers and status
+// are recorded for the invokeStream goroutine's final event.
+func TestStreamEventHandlerOnReceiveTrailers(t *testing.T) {
+	h := &streamEventHandler{}
+	md := metadata.MD{"k": []string{"v"}}
+	s := status.New(0, "ok")
+
+	h.OnReceiveTrailers(s, md)
+
+	if h.status != s {
+		t.Fatalf("status not recorded")
+	}
+	if got := h.trailers.Get("k"); len(got) != 1 || got[0] != "v" {
+		t.Fatalf("trailers not recorded, got %v", h.trailers)
+	}
+}